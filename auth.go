@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/sheets/v4"
+)
+
+// connectSheetsClientWithServiceAccount builds a Sheets client authenticated
+// with a service account JSON key read from keyPath. It returns the
+// configured Client.
+func connectSheetsClientWithServiceAccount(keyPath string, scopes ...string) (*sheets.Service, error) {
+	ctx := context.Background()
+
+	b, err := ioutil.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read service account key file: %w", err)
+	}
+
+	jwtConfig, err := google.JWTConfigFromJSON(b, scopes...)
+	if err != nil {
+		return nil, err
+	}
+
+	client := jwtConfig.Client(ctx)
+	return sheets.New(client)
+}
+
+// connectSheetsClientADC builds a Sheets client using Application Default
+// Credentials, e.g. a GOOGLE_APPLICATION_CREDENTIALS key file, a GCE/GKE
+// metadata service, or gcloud's local user credentials.
+func connectSheetsClientADC(ctx context.Context, scopes ...string) (*sheets.Service, error) {
+	creds, err := google.FindDefaultCredentials(ctx, scopes...)
+	if err != nil {
+		return nil, err
+	}
+
+	client := oauth2.NewClient(ctx, creds.TokenSource)
+	return sheets.New(client)
+}
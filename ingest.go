@@ -0,0 +1,213 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"io"
+	"math"
+	"time"
+
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/sheets/v4"
+)
+
+// maxCellsPerBatch keeps each Values.Append request comfortably under the
+// Sheets API's per-request cell-count limit.
+const maxCellsPerBatch = 10000
+
+// IngestOptions controls how rows are chunked and retried while streaming
+// into a sheet.
+type IngestOptions struct {
+	// BatchSize is the number of rows sent per Values.Append call. If zero,
+	// it is derived from the row width and maxCellsPerBatch.
+	BatchSize int
+	// MaxRetries is the number of retry attempts for a batch that fails
+	// with a retryable (429/5xx) error. If zero, defaults to 5.
+	MaxRetries int
+}
+
+// IngestCSV reads rows from r as CSV and streams them into tab, appending
+// in batches.
+func IngestCSV(srv *sheets.Service, sheetID string, tab string, r io.Reader, opts IngestOptions) error {
+	reader := csv.NewReader(r)
+	batchSize := opts.BatchSize
+
+	var batch [][]interface{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if batchSize == 0 {
+			batchSize = rowsPerBatch(len(record))
+		}
+
+		row := make([]interface{}, len(record))
+		for i, v := range record {
+			row[i] = v
+		}
+		batch = append(batch, row)
+
+		if len(batch) >= batchSize {
+			if err := appendWithRetry(srv, sheetID, tab, batch, opts.MaxRetries); err != nil {
+				return err
+			}
+			batch = nil
+		}
+	}
+	if len(batch) > 0 {
+		return appendWithRetry(srv, sheetID, tab, batch, opts.MaxRetries)
+	}
+	return nil
+}
+
+// IngestSQL reads rows from an already-executed *sql.Rows and streams them
+// into tab, appending in batches.
+func IngestSQL(srv *sheets.Service, sheetID string, tab string, rows *sql.Rows, opts IngestOptions) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	batchSize := opts.BatchSize
+	if batchSize == 0 {
+		batchSize = rowsPerBatch(len(columns))
+	}
+
+	values := make([]interface{}, len(columns))
+	scanArgs := make([]interface{}, len(columns))
+	for i := range values {
+		scanArgs[i] = &values[i]
+	}
+
+	var batch [][]interface{}
+	for rows.Next() {
+		if err := rows.Scan(scanArgs...); err != nil {
+			return err
+		}
+		row := make([]interface{}, len(values))
+		for i, v := range values {
+			row[i] = normalizeSQLValue(v)
+		}
+		batch = append(batch, row)
+
+		if len(batch) >= batchSize {
+			if err := appendWithRetry(srv, sheetID, tab, batch, opts.MaxRetries); err != nil {
+				return err
+			}
+			batch = nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(batch) > 0 {
+		return appendWithRetry(srv, sheetID, tab, batch, opts.MaxRetries)
+	}
+	return nil
+}
+
+// IngestChan drains rows as they arrive on rows and streams them into tab,
+// appending in batches. It returns once rows is closed, or when a batch
+// fails with a non-retryable error or after exhausting retries.
+func IngestChan(srv *sheets.Service, sheetID string, tab string, rows <-chan []interface{}, opts IngestOptions) error {
+	batchSize := opts.BatchSize
+
+	var batch [][]interface{}
+	for row := range rows {
+		if batchSize == 0 {
+			batchSize = rowsPerBatch(len(row))
+		}
+		batch = append(batch, row)
+
+		if len(batch) >= batchSize {
+			if err := appendWithRetry(srv, sheetID, tab, batch, opts.MaxRetries); err != nil {
+				return err
+			}
+			batch = nil
+		}
+	}
+	if len(batch) > 0 {
+		return appendWithRetry(srv, sheetID, tab, batch, opts.MaxRetries)
+	}
+	return nil
+}
+
+// normalizeSQLValue converts a value scanned from *sql.Rows into something
+// safe to marshal into a Sheets ValueRange. Drivers such as
+// go-sql-driver/mysql scan text/varchar/blob columns into []byte rather
+// than string when the destination is interface{}; left as-is, encoding/json
+// would base64-encode that []byte, turning text columns into garbage in the
+// sheet. time.Time is likewise rendered as text rather than relying on its
+// default JSON encoding.
+func normalizeSQLValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case []byte:
+		return string(val)
+	case time.Time:
+		return val.Format(time.RFC3339)
+	default:
+		return v
+	}
+}
+
+// rowsPerBatch picks a row count that keeps batches under
+// maxCellsPerBatch for a row of the given width.
+func rowsPerBatch(width int) int {
+	if width == 0 {
+		width = 1
+	}
+	n := maxCellsPerBatch / width
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// appendWithRetry calls Values.Append for batch, retrying with exponential
+// backoff on 429 (rate limit) or 5xx (server) errors.
+func appendWithRetry(srv *sheets.Service, sheetID string, tab string, batch [][]interface{}, maxRetries int) error {
+	if maxRetries == 0 {
+		maxRetries = 5
+	}
+
+	valueRange := &sheets.ValueRange{MajorDimension: "ROWS", Values: batch}
+
+	var err error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		_, err = srv.Spreadsheets.Values.Append(sheetID, tab, valueRange).
+			ValueInputOption("USER_ENTERED").Do()
+		if err == nil {
+			return nil
+		}
+		if !isRetryableError(err) || attempt == maxRetries {
+			return err
+		}
+		time.Sleep(backoffDuration(attempt))
+	}
+	return err
+}
+
+// isRetryableError reports whether err looks like a transient 429 or 5xx
+// response from the Sheets API.
+func isRetryableError(err error) bool {
+	apiErr, ok := err.(*googleapi.Error)
+	if !ok {
+		return false
+	}
+	return apiErr.Code == 429 || apiErr.Code >= 500
+}
+
+// backoffDuration returns an exponential backoff delay for the given retry
+// attempt (0-indexed), capped at 30 seconds.
+func backoffDuration(attempt int) time.Duration {
+	d := time.Duration(math.Pow(2, float64(attempt))) * time.Second
+	if d > 30*time.Second {
+		d = 30 * time.Second
+	}
+	return d
+}
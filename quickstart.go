@@ -1,16 +1,21 @@
 package main
 
 import (
+	"crypto/rand"
+	"encoding/base64"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"os/exec"
 	"os/user"
 	"path/filepath"
+	"runtime"
 	"strconv"
 	"time"
 
@@ -22,36 +27,114 @@ import (
 
 // getClient uses a Context and Config to retrieve a Token
 // then generate a Client. It returns the generated Client.
+//
+// The returned client's transport is backed by a TokenManager, so an
+// expired access token is refreshed automatically on the next request and
+// the cache file is kept in sync, rather than going stale until the next
+// full re-auth.
 func getClient(ctx context.Context, config *oauth2.Config) *http.Client {
 	cacheFile, err := tokenCacheFile()
 	if err != nil {
 		log.Fatalf("Unable to get path to cached credential file. %v", err)
 	}
-	tok, err := tokenFromFile(cacheFile)
+	tm, err := NewTokenManager(ctx, config, cacheFile)
 	if err != nil {
-		tok = getTokenFromWeb(config)
-		saveToken(cacheFile, tok)
+		log.Fatalf("Unable to set up token manager. %v", err)
 	}
-	return config.Client(ctx, tok)
+	return oauth2.NewClient(ctx, tm)
 }
 
-// getTokenFromWeb uses Config to request a Token.
+// getTokenFromWeb uses Config to request a Token by running the OAuth flow
+// through a local loopback redirect instead of the copy-paste code flow.
 // It returns the retrieved Token.
 func getTokenFromWeb(config *oauth2.Config) *oauth2.Token {
-	authURL := config.AuthCodeURL("state-token", oauth2.AccessTypeOffline)
-	fmt.Printf("Go to the following link in your browser then type the "+
-		"authorization code: \n%v\n", authURL)
-
-	var code string
-	if _, err := fmt.Scan(&code); err != nil {
-		log.Fatalf("Unable to read authorization code %v", err)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		log.Fatalf("Unable to start loopback listener: %v", err)
 	}
+	defer listener.Close()
 
-	tok, err := config.Exchange(oauth2.NoContext, code)
+	config.RedirectURL = fmt.Sprintf("http://127.0.0.1:%d", listener.Addr().(*net.TCPAddr).Port)
+
+	state, err := randomState()
 	if err != nil {
-		log.Fatalf("Unable to retrieve token from web %v", err)
+		log.Fatalf("Unable to generate state token: %v", err)
+	}
+
+	type result struct {
+		code string
+		err  error
+	}
+	resultCh := make(chan result, 1)
+
+	mux := http.NewServeMux()
+	server := &http.Server{Handler: mux}
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("state"); got != state {
+			http.Error(w, "state mismatch", http.StatusBadRequest)
+			resultCh <- result{err: fmt.Errorf("state mismatch: got %q, want %q", got, state)}
+			return
+		}
+		code := r.URL.Query().Get("code")
+		if code == "" {
+			http.Error(w, "missing code", http.StatusBadRequest)
+			resultCh <- result{err: fmt.Errorf("no code in callback request")}
+			return
+		}
+		fmt.Fprint(w, "Authentication complete. You may close this tab.")
+		resultCh <- result{code: code}
+	})
+
+	go server.Serve(listener)
+	defer server.Close()
+
+	authURL := config.AuthCodeURL(state, oauth2.AccessTypeOffline)
+	fmt.Printf("Opening the following link in your browser to authorize access: \n%v\n", authURL)
+	if err := openBrowser(authURL); err != nil {
+		fmt.Printf("Unable to open browser automatically, please open the link manually: %v\n", err)
+	}
+
+	select {
+	case res := <-resultCh:
+		if res.err != nil {
+			log.Fatalf("Unable to complete authorization: %v", res.err)
+		}
+		tok, err := config.Exchange(oauth2.NoContext, res.code)
+		if err != nil {
+			log.Fatalf("Unable to retrieve token from web %v", err)
+		}
+		return tok
+	case <-time.After(2 * time.Minute):
+		log.Fatalf("Timed out waiting for authorization")
+		return nil
 	}
-	return tok
+}
+
+// randomState generates a random, URL-safe CSRF state value.
+func randomState() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(b), nil
+}
+
+// openBrowser opens url in the user's default browser.
+func openBrowser(url string) error {
+	var cmd string
+	var args []string
+
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = "open"
+	case "windows":
+		cmd = "rundll32"
+		args = []string{"url.dll,FileProtocolHandler"}
+	default:
+		cmd = "xdg-open"
+	}
+	args = append(args, url)
+	return exec.Command(cmd, args...).Start()
 }
 
 // tokenCacheFile generates credential file path/filename.
@@ -128,10 +211,32 @@ func connectSheetsClient() (*sheets.Service, error) {
 	return srv, err
 }
 
+// sheetsScope is the OAuth/service-account scope requested for all auth modes.
+const sheetsScope = "https://www.googleapis.com/auth/spreadsheets"
+
 var sheetID string
+var authMode string
+var serviceAccountKeyPath string
 
 func init() {
 	flag.StringVar(&sheetID, "id", "", "found in the URL of the sheet")
+	flag.StringVar(&authMode, "auth", "oauth", "authentication mode: oauth, serviceaccount, or adc")
+	flag.StringVar(&serviceAccountKeyPath, "keyfile", "service_account.json", "path to the service account JSON key (used with -auth=serviceaccount)")
+}
+
+// connectSheets picks an authenticated Sheets client based on the -auth flag.
+func connectSheets() (*sheets.Service, error) {
+	switch authMode {
+	case "serviceaccount":
+		return connectSheetsClientWithServiceAccount(serviceAccountKeyPath, sheetsScope)
+	case "adc":
+		return connectSheetsClientADC(context.Background(), sheetsScope)
+	case "oauth":
+		return connectSheetsClient()
+	default:
+		log.Fatalf("Unknown -auth mode %q, expected oauth, serviceaccount, or adc", authMode)
+		return nil, nil
+	}
 }
 
 func main() {
@@ -140,7 +245,7 @@ func main() {
 	fmt.Printf("id: %v\n", sheetID)
 
 	fmt.Println("Connecting to Sheets API")
-	srv, err := connectSheetsClient()
+	srv, err := connectSheets()
 	if err != nil {
 		log.Fatalf("Unable to retrieve Sheets Client %v", err)
 	}
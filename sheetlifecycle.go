@@ -0,0 +1,73 @@
+package main
+
+import "google.golang.org/api/sheets/v4"
+
+// SheetOptions configures the properties of a tab created via AddSheet.
+type SheetOptions struct {
+	TabColor          *sheets.Color
+	Hidden            bool
+	FrozenRowCount    int64
+	FrozenColumnCount int64
+}
+
+// AddSheet creates a new tab titled title in the spreadsheet and returns its
+// numeric sheetId.
+func AddSheet(srv *sheets.Service, spreadsheetID string, title string, opts SheetOptions) (int64, error) {
+	req := &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{
+			{
+				AddSheet: &sheets.AddSheetRequest{
+					Properties: &sheets.SheetProperties{
+						Title:    title,
+						Hidden:   opts.Hidden,
+						TabColor: opts.TabColor,
+						GridProperties: &sheets.GridProperties{
+							FrozenRowCount:    opts.FrozenRowCount,
+							FrozenColumnCount: opts.FrozenColumnCount,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	resp, err := srv.Spreadsheets.BatchUpdate(spreadsheetID, req).Do()
+	if err != nil {
+		return 0, err
+	}
+	return resp.Replies[0].AddSheet.Properties.SheetId, nil
+}
+
+// DeleteSheet removes the tab identified by sheetID from the spreadsheet.
+func DeleteSheet(srv *sheets.Service, spreadsheetID string, sheetID int64) error {
+	req := &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{
+			{
+				DeleteSheet: &sheets.DeleteSheetRequest{
+					SheetId: sheetID,
+				},
+			},
+		},
+	}
+	_, err := srv.Spreadsheets.BatchUpdate(spreadsheetID, req).Do()
+	return err
+}
+
+// RenameSheet sets the title of the tab identified by sheetID.
+func RenameSheet(srv *sheets.Service, spreadsheetID string, sheetID int64, newTitle string) error {
+	req := &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{
+			{
+				UpdateSheetProperties: &sheets.UpdateSheetPropertiesRequest{
+					Properties: &sheets.SheetProperties{
+						SheetId: sheetID,
+						Title:   newTitle,
+					},
+					Fields: "title",
+				},
+			},
+		},
+	}
+	_, err := srv.Spreadsheets.BatchUpdate(spreadsheetID, req).Do()
+	return err
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// RangeValues pairs an A1 range with the values to write there, for use
+// with BatchUpdateValues.
+type RangeValues struct {
+	Range  string
+	Values [][]interface{}
+}
+
+// BatchUpdateValues submits many ranges in a single Values.BatchUpdate
+// request instead of one Values.Update call per range.
+func BatchUpdateValues(srv *sheets.Service, sheetID string, ranges []RangeValues) error {
+	data := make([]*sheets.ValueRange, 0, len(ranges))
+	for _, r := range ranges {
+		data = append(data, &sheets.ValueRange{
+			Range:          r.Range,
+			MajorDimension: "ROWS",
+			Values:         r.Values,
+		})
+	}
+
+	req := &sheets.BatchUpdateValuesRequest{
+		ValueInputOption: "USER_ENTERED",
+		Data:             data,
+	}
+	_, err := srv.Spreadsheets.Values.BatchUpdate(sheetID, req).Do()
+	return err
+}
+
+// WriteCells appends rows to sheetName as typed cells, preserving number and
+// boolean types instead of letting USER_ENTERED value input coerce
+// everything to strings. A string value starting with "=" is written as a
+// formula.
+func WriteCells(srv *sheets.Service, sheetID string, sheetName string, rows [][]interface{}) error {
+	sheetNumericID, err := sheetNameToID(srv, sheetID, sheetName)
+	if err != nil {
+		return err
+	}
+
+	rowData := make([]*sheets.RowData, 0, len(rows))
+	for _, row := range rows {
+		values := make([]*sheets.CellData, 0, len(row))
+		for _, cell := range row {
+			cellData, err := toCellData(cell)
+			if err != nil {
+				return err
+			}
+			values = append(values, cellData)
+		}
+		rowData = append(rowData, &sheets.RowData{Values: values})
+	}
+
+	req := &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{
+			{
+				AppendCells: &sheets.AppendCellsRequest{
+					SheetId: sheetNumericID,
+					Rows:    rowData,
+					Fields:  "userEnteredValue",
+				},
+			},
+		},
+	}
+	_, err = srv.Spreadsheets.BatchUpdate(sheetID, req).Do()
+	return err
+}
+
+// toCellData converts a Go value into a CellData with the ExtendedValue
+// field that matches its type. It returns an error for types it doesn't
+// know how to represent, rather than silently dropping the value.
+func toCellData(v interface{}) (*sheets.CellData, error) {
+	switch val := v.(type) {
+	case string:
+		if strings.HasPrefix(val, "=") {
+			return &sheets.CellData{UserEnteredValue: &sheets.ExtendedValue{FormulaValue: &val}}, nil
+		}
+		return &sheets.CellData{UserEnteredValue: &sheets.ExtendedValue{StringValue: &val}}, nil
+	case bool:
+		return &sheets.CellData{UserEnteredValue: &sheets.ExtendedValue{BoolValue: &val}}, nil
+	case int:
+		n := float64(val)
+		return &sheets.CellData{UserEnteredValue: &sheets.ExtendedValue{NumberValue: &n}}, nil
+	case int32:
+		n := float64(val)
+		return &sheets.CellData{UserEnteredValue: &sheets.ExtendedValue{NumberValue: &n}}, nil
+	case int64:
+		n := float64(val)
+		return &sheets.CellData{UserEnteredValue: &sheets.ExtendedValue{NumberValue: &n}}, nil
+	case uint:
+		n := float64(val)
+		return &sheets.CellData{UserEnteredValue: &sheets.ExtendedValue{NumberValue: &n}}, nil
+	case uint64:
+		n := float64(val)
+		return &sheets.CellData{UserEnteredValue: &sheets.ExtendedValue{NumberValue: &n}}, nil
+	case float32:
+		n := float64(val)
+		return &sheets.CellData{UserEnteredValue: &sheets.ExtendedValue{NumberValue: &n}}, nil
+	case float64:
+		return &sheets.CellData{UserEnteredValue: &sheets.ExtendedValue{NumberValue: &val}}, nil
+	case time.Time:
+		s := val.Format(time.RFC3339)
+		return &sheets.CellData{UserEnteredValue: &sheets.ExtendedValue{StringValue: &s}}, nil
+	case nil:
+		return &sheets.CellData{}, nil
+	default:
+		return nil, fmt.Errorf("toCellData: unsupported cell value type %T", v)
+	}
+}
+
+// sheetNameToID resolves a tab's title to its numeric sheetId, as required
+// by AppendCellsRequest. It returns an error if the lookup fails or no tab
+// named sheetName exists, since 0 is also the valid sheetId of a
+// spreadsheet's first tab and can't be used as a not-found sentinel.
+func sheetNameToID(srv *sheets.Service, spreadsheetID string, sheetName string) (int64, error) {
+	ss, err := srv.Spreadsheets.Get(spreadsheetID).Do()
+	if err != nil {
+		return 0, err
+	}
+	for _, sheet := range ss.Sheets {
+		if sheet.Properties.Title == sheetName {
+			return sheet.Properties.SheetId, nil
+		}
+	}
+	return 0, fmt.Errorf("sheetNameToID: no tab named %q in spreadsheet %q", sheetName, spreadsheetID)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+// staleLockAge is how old a ".lock" file can be before TokenManager assumes
+// the process that created it is gone (crashed, killed, OOM-killed) and
+// breaks the lock rather than waiting out the full timeout.
+const staleLockAge = 30 * time.Second
+
+// TokenManager wraps an oauth2.TokenSource so that every call to Token
+// triggers a silent refresh when the cached token has expired, and
+// rewrites the cache file only when the source actually issued a new
+// token. This replaces the old cache-and-forget behavior where a stale
+// cache file forced a full re-auth once the access token expired.
+type TokenManager struct {
+	mu        sync.Mutex
+	cachePath string
+	source    oauth2.TokenSource
+	current   *oauth2.Token
+}
+
+// NewTokenManager loads (or creates, via the OAuth web flow) a token for
+// config and returns a TokenManager that keeps cachePath in sync with it.
+func NewTokenManager(ctx context.Context, config *oauth2.Config, cachePath string) (*TokenManager, error) {
+	tok, err := tokenFromFile(cachePath)
+	if err != nil {
+		tok = getTokenFromWeb(config)
+		saveToken(cachePath, tok)
+	}
+	return &TokenManager{
+		cachePath: cachePath,
+		source:    config.TokenSource(ctx, tok),
+		current:   tok,
+	}, nil
+}
+
+// Token returns a valid token, refreshing it if necessary. The cache file
+// is only locked and rewritten when the source hands back a different
+// token than the one already held, not on every call.
+func (m *TokenManager) Token() (*oauth2.Token, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tok, err := m.source.Token()
+	if err != nil {
+		return nil, err
+	}
+	if m.current != nil && tokensEqual(m.current, tok) {
+		return tok, nil
+	}
+	m.current = tok
+
+	unlock, err := lockCacheFile(m.cachePath)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	saveToken(m.cachePath, tok)
+	return tok, nil
+}
+
+// tokensEqual reports whether two tokens represent the same credential, so
+// TokenManager can tell a silently-reused token from an actual refresh.
+func tokensEqual(a, b *oauth2.Token) bool {
+	return a.AccessToken == b.AccessToken && a.RefreshToken == b.RefreshToken
+}
+
+// lockCacheFile takes a simple advisory lock on path using a sibling
+// ".lock" file, so that concurrent invocations of this tool don't
+// interleave writes to the token cache. It blocks until the lock is
+// acquired or the timeout elapses, breaking the lock first if it's older
+// than staleLockAge, since a lock file left behind by a killed process
+// would otherwise wedge every future invocation.
+func lockCacheFile(path string) (unlock func(), err error) {
+	lockPath := path + ".lock"
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if info, statErr := os.Stat(lockPath); statErr == nil && time.Since(info.ModTime()) > staleLockAge {
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for lock on %s", lockPath)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}